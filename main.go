@@ -1,11 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
+	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"math/rand"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/schollz/progressbar/v3"
@@ -19,9 +27,42 @@ const (
 	DataLength    = 8       // DLC fixed to 8 bytes
 )
 
+// Golden scenario used for cross-tool benchmarking. Every parameter of the
+// run is pinned so any build of the tool produces a byte-identical dataset
+// whose SHA-256 must equal GoldenSHA256. Changing any of these values, the
+// DBC table, or the generation logic requires updating GoldenSHA256.
+const (
+	GoldenSeed          = 42                     // Seed for the random number generator
+	GoldenNormalCount   = 8700                   // Number of normal messages
+	GoldenInjectedCount = 1300                   // Number of injected messages
+	GoldenStartUnix     = 1700000000             // Timestamp of the first frame (UNIX seconds)
+	GoldenInterval      = 500 * time.Microsecond // Spacing between consecutive frames
+	GoldenFilename      = "Golden_dataset.csv"
+	GoldenSHA256        = "0dc8439b441bda3be6bd8c435c6510f3e15f6957e0bbf289ecdd460c5a4c6531"
+)
+
 // Counters to track the number of normal and injected messages generated
 var normalMessages, injectedMessages int
 
+// Target counts for the current run, overridden by the golden preset
+var normalCount, injectedCount = NormalCount, InjectedCount
+
+// Random number generator and clock for the current run, overridden by the golden preset
+var (
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	clock = time.Now
+)
+
+// Fixed clock that advances by interval on every call, starting at start
+func steppedClock(start time.Time, interval time.Duration) func() time.Time {
+	next := start
+	return func() time.Time {
+		now := next
+		next = next.Add(interval)
+		return now
+	}
+}
+
 // Predefined DBC-like data for normal CAN messages with fluctuating ranges
 var DBC = map[uint32]func() [8]byte{
 	0x100: func() [8]byte { return [8]byte{byte(toggleOnOff()), 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00} },      // EngineOnOff (fluctuates between on/off)
@@ -36,14 +77,49 @@ var DBC = map[uint32]func() [8]byte{
 	}, // EngineRPM (2500 - 3000 RPM)
 }
 
+// DBC IDs in ascending order so a seeded run picks the same IDs every time
+var dbcKeys = slices.Sorted(maps.Keys(DBC))
+
+// Signal describes how a physical value is laid out in a CAN frame payload
+type Signal struct {
+	Name      string
+	StartBit  int  // DBC start bit (MSB position for big-endian signals)
+	Length    int  // Length in bits
+	BigEndian bool // Motorola byte order when true, Intel otherwise
+	Factor    float64
+	Offset    float64
+	Min       float64
+	Max       float64
+	Unit      string
+}
+
+// Message describes a CAN message and the signals it carries
+type Message struct {
+	Name    string
+	Signals []Signal
+}
+
+// Signal metadata matching the payloads produced by DBC, used for DBC export
+var Messages = map[uint32]Message{
+	0x100: {"EngineOnOff", []Signal{{"EngineOnOff", 0, 8, false, 1, 0, 0, 1, ""}}},
+	0x101: {"FrontLight", []Signal{{"FrontLight", 0, 8, false, 1, 0, 0, 1, ""}}},
+	0x200: {"EngineTempSensor", []Signal{{"EngineTemp", 0, 8, false, 1, 0, 80, 100, "degC"}}},
+	0x201: {"InjectorTimingSensor", []Signal{{"InjectorTiming", 0, 8, false, 1, 0, 60, 90, "ms"}}},
+	0x202: {"OxygenSensor", []Signal{{"Oxygen", 0, 8, false, 1, 0, 90, 100, "%"}}},
+	0x203: {"FuelTankLevel", []Signal{{"FuelLevel", 0, 8, false, 1, 0, 60, 80, "%"}}},
+	0x204: {"ThrottlePosition", []Signal{{"Throttle", 0, 8, false, 1, 0, 40, 60, "%"}}},
+	// EngineRPM draws its high and low bytes independently, so decoded values span 0x0900 - 0x0BFF
+	0x205: {"EngineRPM", []Signal{{"EngineRPM", 7, 16, true, 1, 0, 2304, 3071, "rpm"}}},
+}
+
 // Helper function to generate random fluctuations within a range
 func fluctuate(min, max int) int {
-	return min + rand.Intn(max-min+1)
+	return min + rng.Intn(max-min+1)
 }
 
 // Helper function to randomly toggle on/off (1 for on, 0 for off)
 func toggleOnOff() int {
-	if rand.Float64() < 0.5 {
+	if rng.Float64() < 0.5 {
 		return 1 // on
 	}
 	return 0 // off
@@ -55,21 +131,17 @@ func generateCANData() (uint32, [8]byte, string) {
 	var data [8]byte
 	var flag string
 
-	if injectedMessages < InjectedCount && (normalMessages >= NormalCount || rand.Float64() < 0.5) {
+	if injectedMessages < injectedCount && (normalMessages >= normalCount || rng.Float64() < 0.5) {
 		// Generate injected message
-		canID = uint32(rand.Intn(0x300-0x206) + 0x206) // Random ID outside DBC range
+		canID = uint32(rng.Intn(0x300-0x206) + 0x206) // Random ID outside DBC range
 		for i := 0; i < DataLength; i++ {
-			data[i] = byte(rand.Intn(256))
+			data[i] = byte(rng.Intn(256))
 		}
 		flag = "T"
 		injectedMessages++
-	} else if normalMessages < NormalCount {
+	} else if normalMessages < normalCount {
 		// Generate normal message with fluctuating sensor data
-		dbcKeys := make([]uint32, 0, len(DBC))
-		for k := range DBC {
-			dbcKeys = append(dbcKeys, k)
-		}
-		canID = dbcKeys[rand.Intn(len(dbcKeys))]
+		canID = dbcKeys[rng.Intn(len(dbcKeys))]
 		data = DBC[canID]() // Call function to generate fluctuating data
 		flag = "R"
 		normalMessages++
@@ -78,8 +150,18 @@ func generateCANData() (uint32, [8]byte, string) {
 	return canID, data, flag
 }
 
-// Function to generate and save dataset as a CSV file
-func generateDataset(filename string) error {
+// Function to pick the bus a frame is sent on. Injected frames only land on
+// attackChannels, normal traffic is spread over all channels.
+func pickChannel(flag string, channels, attackChannels []string) string {
+	if flag == "T" {
+		return attackChannels[rng.Intn(len(attackChannels))]
+	}
+	return channels[rng.Intn(len(channels))]
+}
+
+// Function to generate and save dataset as a CSV file. When channels is
+// non-empty a channel column is appended after the flag.
+func generateDataset(filename string, channels, attackChannels []string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("could not create file: %v", err)
@@ -89,8 +171,10 @@ func generateDataset(filename string) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
+	totalRecords := normalCount + injectedCount
+
 	// Initialize progress bar
-	bar := progressbar.NewOptions(TotalRecords,
+	bar := progressbar.NewOptions(totalRecords,
 		progressbar.OptionSetDescription("Generating CAN dataset"),
 		progressbar.OptionShowCount(),
 		progressbar.OptionSetWidth(40),
@@ -102,7 +186,7 @@ func generateDataset(filename string) error {
 		}))
 
 	// Generate CAN data and write to CSV
-	for i := 0; i < TotalRecords; i++ {
+	for i := 0; i < totalRecords; i++ {
 		timestamp := formatTimestamp() // Generate UNIX timestamp with microsecond precision
 		canID, data, flag := generateCANData()
 
@@ -118,6 +202,9 @@ func generateDataset(filename string) error {
 		}
 
 		record = append(record, flag)
+		if len(channels) > 0 {
+			record = append(record, pickChannel(flag, channels, attackChannels))
+		}
 		if err := writer.Write(record); err != nil {
 			return fmt.Errorf("could not write record: %v", err)
 		}
@@ -128,21 +215,167 @@ func generateDataset(filename string) error {
 	return nil
 }
 
+// Function to export the message/signal table as a DBC file
+func exportDBC(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("could not create file: %v", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "VERSION \"\"\n\n")
+	fmt.Fprintf(w, "NS_ :\n\n")
+	fmt.Fprintf(w, "BS_:\n\n")
+	fmt.Fprintf(w, "BU_: ECU\n\n")
+
+	// Sort IDs so the exported file is stable between runs
+	for _, id := range slices.Sorted(maps.Keys(Messages)) {
+		msg := Messages[id]
+		fmt.Fprintf(w, "BO_ %d %s: %d ECU\n", id, msg.Name, DataLength)
+		for _, sig := range msg.Signals {
+			byteOrder := 1 // Intel (little-endian)
+			if sig.BigEndian {
+				byteOrder = 0 // Motorola (big-endian)
+			}
+			fmt.Fprintf(w, " SG_ %s : %d|%d@%d+ (%s,%s) [%s|%s] \"%s\" Vector__XXX\n",
+				sig.Name, sig.StartBit, sig.Length, byteOrder,
+				formatFloat(sig.Factor), formatFloat(sig.Offset),
+				formatFloat(sig.Min), formatFloat(sig.Max), sig.Unit)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("could not write DBC: %v", err)
+	}
+	return nil
+}
+
+// Helper function to format DBC numbers without trailing zeros
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Helper function to split a comma-separated channel list, dropping empty entries
+func parseChannels(list string) []string {
+	var channels []string
+	for _, ch := range strings.Split(list, ",") {
+		if ch = strings.TrimSpace(ch); ch != "" {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+// Function to check that channel lists have no duplicates and that every
+// attack channel is one of the configured channels
+func validateChannels(channels, attackChannels []string) error {
+	if len(attackChannels) > 0 && len(channels) == 0 {
+		return fmt.Errorf("-attack-channels requires -channels")
+	}
+	known := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		if known[ch] {
+			return fmt.Errorf("channel %q listed more than once in -channels", ch)
+		}
+		known[ch] = true
+	}
+	seen := make(map[string]bool, len(attackChannels))
+	for _, ch := range attackChannels {
+		if seen[ch] {
+			return fmt.Errorf("channel %q listed more than once in -attack-channels", ch)
+		}
+		seen[ch] = true
+		if !known[ch] {
+			return fmt.Errorf("attack channel %q is not in -channels", ch)
+		}
+	}
+	return nil
+}
+
+// Function to switch the generator to the golden scenario, returning its output filename
+func applyGoldenPreset() string {
+	rng = rand.New(rand.NewSource(GoldenSeed))
+	clock = steppedClock(time.Unix(GoldenStartUnix, 0), GoldenInterval)
+	normalCount, injectedCount = GoldenNormalCount, GoldenInjectedCount
+	normalMessages, injectedMessages = 0, 0
+	return GoldenFilename
+}
+
+// Function to compute the hex-encoded SHA-256 of a file
+func fileSHA256(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %v", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("could not read file: %v", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // Function to format timestamp as UNIX time with microsecond precision
 func formatTimestamp() string {
-	now := time.Now()
+	now := clock()
 	seconds := now.Unix()
 	microseconds := now.UnixMicro() - (seconds * 1e6)
 	return fmt.Sprintf("%d.%06d", seconds, microseconds)
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	exportDBCFile := flag.String("export-dbc", "", "also write the message/signal table as a DBC file to this path")
+	channelList := flag.String("channels", "", "comma-separated bus names; adds a channel column and spreads frames across them")
+	attackChannelList := flag.String("attack-channels", "", "comma-separated subset of -channels that injected frames are limited to (default: all channels)")
+	golden := flag.Bool("golden", false, "generate the pinned golden scenario and verify its SHA-256")
+	flag.Parse()
+
+	channels := parseChannels(*channelList)
+	attackChannels := parseChannels(*attackChannelList)
+	if err := validateChannels(channels, attackChannels); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(attackChannels) == 0 {
+		attackChannels = channels
+	}
 
 	filename := "Fuzzy_dataset.csv"
-	if err := generateDataset(filename); err != nil {
+	if *golden {
+		if len(channels) > 0 {
+			fmt.Printf("Error: -golden cannot be combined with -channels\n")
+			os.Exit(1)
+		}
+		filename = applyGoldenPreset()
+	}
+
+	if *exportDBCFile != "" {
+		if err := exportDBC(*exportDBCFile); err != nil {
+			fmt.Printf("Error exporting DBC: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("DBC exported to %s\n", *exportDBCFile)
+	}
+
+	if err := generateDataset(filename, channels, attackChannels); err != nil {
 		fmt.Printf("Error generating dataset: %v\n", err)
-	} else {
-		fmt.Printf("\nDataset generated successfully and saved to %s\n", filename)
+		return
+	}
+	fmt.Printf("\nDataset generated successfully and saved to %s\n", filename)
+
+	if *golden {
+		sum, err := fileSHA256(filename)
+		if err != nil {
+			fmt.Printf("Error hashing dataset: %v\n", err)
+			os.Exit(1)
+		}
+		if sum != GoldenSHA256 {
+			fmt.Printf("Golden dataset hash mismatch: got %s, want %s\n", sum, GoldenSHA256)
+			os.Exit(1)
+		}
+		fmt.Printf("Golden dataset hash verified: %s\n", sum)
 	}
 }